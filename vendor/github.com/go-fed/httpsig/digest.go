@@ -0,0 +1,69 @@
+package httpsig
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// DigestAlgorithm is the string representation of a digest algorithm used to
+// compute the "Digest" header, as in RFC 3230.
+type DigestAlgorithm string
+
+const (
+	DigestSha256 DigestAlgorithm = "SHA-256"
+	DigestSha512 DigestAlgorithm = "SHA-512"
+
+	digestHeader = "Digest"
+)
+
+// new returns a fresh hash.Hash for the digest algorithm.
+func (d DigestAlgorithm) new() (hash.Hash, error) {
+	switch d {
+	case DigestSha256:
+		return sha256.New(), nil
+	case DigestSha512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %q", d)
+	}
+}
+
+// addDigest computes "<algo>=<base64>" for body, sets it as the Digest
+// header on h, and returns headers with the digest header appended so that
+// it is included in the signature, unless it is already present.
+func addDigest(h http.Header, algo DigestAlgorithm, body []byte, headers []string) ([]string, error) {
+	hasher, err := algo.new()
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(body)
+	sum := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+	h.Set(digestHeader, fmt.Sprintf("%s=%s", algo, sum))
+	return ensureHeader(headers, digestHeader), nil
+}
+
+// ensureHeader returns headers with header appended if it is not already
+// present (case-insensitively).
+func ensureHeader(headers []string, header string) []string {
+	for _, h := range headers {
+		if strings.EqualFold(h, header) {
+			return headers
+		}
+	}
+	return append(headers, header)
+}
+
+// parseDigestHeader splits a "<algo>=<base64>" Digest header value into its
+// algorithm and digest, reporting ok=false if it is malformed.
+func parseDigestHeader(value string) (algo DigestAlgorithm, sum string, ok bool) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return DigestAlgorithm(strings.ToUpper(parts[0])), parts[1], true
+}
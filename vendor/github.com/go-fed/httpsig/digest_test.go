@@ -0,0 +1,100 @@
+package httpsig
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+func TestDigestAlgorithmNew(t *testing.T) {
+	if _, err := DigestSha256.new(); err != nil {
+		t.Errorf("DigestSha256.new(): %v", err)
+	}
+	if _, err := DigestSha512.new(); err != nil {
+		t.Errorf("DigestSha512.new(): %v", err)
+	}
+	if _, err := DigestAlgorithm("SHA-1").new(); err == nil {
+		t.Error("DigestAlgorithm(\"SHA-1\").new() succeeded, want error")
+	}
+}
+
+func TestAddDigest(t *testing.T) {
+	body := []byte("the quick brown fox jumps over the lazy dog")
+	h := make(http.Header)
+	headers, err := addDigest(h, DigestSha256, body, []string{dateHeader})
+	if err != nil {
+		t.Fatalf("addDigest: %v", err)
+	}
+	if !headersInclude(headers, digestHeader) {
+		t.Fatalf("addDigest did not append %q to headers: %v", digestHeader, headers)
+	}
+
+	sum := sha256.Sum256(body)
+	want := "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+	if got := h.Get(digestHeader); got != want {
+		t.Errorf("Digest header = %q, want %q", got, want)
+	}
+
+	// Calling addDigest again with a header set that already has Digest
+	// must not duplicate the entry.
+	headers, err = addDigest(h, DigestSha256, body, headers)
+	if err != nil {
+		t.Fatalf("addDigest (second call): %v", err)
+	}
+	count := 0
+	for _, hn := range headers {
+		if hn == digestHeader {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("headers contains %q %d times, want 1: %v", digestHeader, count, headers)
+	}
+}
+
+func TestAddDigestSha512(t *testing.T) {
+	body := []byte("another message")
+	h := make(http.Header)
+	if _, err := addDigest(h, DigestSha512, body, nil); err != nil {
+		t.Fatalf("addDigest: %v", err)
+	}
+	sum := sha512.Sum512(body)
+	want := "SHA-512=" + base64.StdEncoding.EncodeToString(sum[:])
+	if got := h.Get(digestHeader); got != want {
+		t.Errorf("Digest header = %q, want %q", got, want)
+	}
+}
+
+func TestEnsureHeader(t *testing.T) {
+	got := ensureHeader([]string{dateHeader}, "Digest")
+	want := []string{dateHeader, "Digest"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("ensureHeader = %v, want %v", got, want)
+	}
+
+	// Already present, case-insensitively: must not append a duplicate.
+	got = ensureHeader([]string{dateHeader, "digest"}, "Digest")
+	if len(got) != 2 {
+		t.Fatalf("ensureHeader duplicated an existing header: %v", got)
+	}
+}
+
+func TestParseDigestHeader(t *testing.T) {
+	algo, sum, ok := parseDigestHeader("SHA-256=abcd")
+	if !ok || algo != DigestSha256 || sum != "abcd" {
+		t.Errorf("parseDigestHeader(\"SHA-256=abcd\") = (%q, %q, %v), want (SHA-256, abcd, true)", algo, sum, ok)
+	}
+
+	// Lowercase algorithm names are normalized to match the DigestSha256/
+	// DigestSha512 constants.
+	algo, _, ok = parseDigestHeader("sha-256=abcd")
+	if !ok || algo != DigestSha256 {
+		t.Errorf("parseDigestHeader(\"sha-256=abcd\") = (%q, _, %v), want (SHA-256, true)", algo, ok)
+	}
+
+	if _, _, ok := parseDigestHeader("malformed"); ok {
+		t.Error("parseDigestHeader(\"malformed\") = ok, want not ok")
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/textproto"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -17,6 +19,8 @@ const (
 	algorithmParameter        = "algorithm"
 	headersParameter          = "headers"
 	signatureParameter        = "signature"
+	createdParameter          = "created"
+	expiresParameter          = "expires"
 	parameterKVSeparater      = "="
 	parameterValueDelimiter   = "\""
 	parameterSeparater        = ","
@@ -24,7 +28,17 @@ const (
 	// RequestTarget specifies to include the http request method and
 	// entire URI in the signature. Pass it as a header to NewSigner.
 	RequestTarget = "(request-target)"
-	dateHeader    = "date"
+	// Created specifies to include the Unix timestamp of when the
+	// signature was created as a pseudo-header. Pass it as a header to
+	// NewSigner; the timestamp is the signing time.
+	Created = "(created)"
+	// Expires specifies to include the Unix timestamp of when the
+	// signature should be considered expired as a pseudo-header. Pass it
+	// as a header to NewSigner; the timestamp is the signing time plus the
+	// signer's expiresIn. Signing fails with an error while expiresIn is not
+	// positive, and no exported option sets expiresIn.
+	Expires    = "(expires)"
+	dateHeader = "date"
 
 	// Signature String Construction
 	headerFieldDelimiter   = ": "
@@ -35,16 +49,96 @@ const (
 
 var defaultHeaders = []string{dateHeader}
 
+// headersInclude reports whether token appears in headers, matching
+// case-insensitively as the rest of this package's header handling does.
+func headersInclude(headers []string, token string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// createdAndExpires computes the `created` and `expires` timestamps for a
+// signing operation whose signed header set is headers. created is only
+// set (to now) if headers actually includes the (created) pseudo-header;
+// expires is only set if headers includes (expires) and expiresIn is
+// positive. Callers that never opted into (created)/(expires) get back
+// (0, 0), so setSignatureHeader omits both parameters as before.
+func createdAndExpires(headers []string, expiresIn time.Duration) (created, expires int64) {
+	now := time.Now().Unix()
+	if headersInclude(headers, Created) {
+		created = now
+	}
+	if headersInclude(headers, Expires) && expiresIn > 0 {
+		expires = now + int64(expiresIn.Seconds())
+	}
+	return
+}
+
+// authScheme returns the auth-scheme token that RFC 7235 requires before
+// credentials placed in the Authorization header. The Signature header
+// carries no such scheme, so only Authorization returns one.
+func (s SignatureScheme) authScheme() string {
+	if s == Authorization {
+		return "Signature"
+	}
+	return ""
+}
+
 var _ Signer = &macSigner{}
 
 type macSigner struct {
-	m            macer
-	headers      []string
-	targetHeader SignatureScheme
+	m               macer
+	headers         []string
+	targetHeader    SignatureScheme
+	digestAlgorithm DigestAlgorithm
+	// prefix is the auth-scheme token written before the signature
+	// parameters, as required when targetHeader is Authorization. It is
+	// derived from targetHeader.authScheme() if left unset.
+	prefix string
+	// expiresIn, if positive, is the lifetime given to signatures via the
+	// expires= parameter and (expires) pseudo-header.
+	expiresIn time.Duration
+}
+
+func (m *macSigner) authPrefix() string {
+	if len(m.prefix) > 0 {
+		return m.prefix
+	}
+	return m.targetHeader.authScheme()
 }
 
 func (m *macSigner) SignRequest(pKey crypto.PrivateKey, pubKeyId string, r *http.Request) error {
-	s, err := m.signatureString(r)
+	created, expires := createdAndExpires(m.headers, m.expiresIn)
+	s, err := signatureString(r.Header, m.headers, addRequestTarget(r), created, expires)
+	if err != nil {
+		return err
+	}
+	enc, err := m.signSignature(pKey, s)
+	if err != nil {
+		return err
+	}
+	setSignatureHeader(r.Header, string(m.targetHeader), m.authPrefix(), pubKeyId, m.m.String(), enc, m.headers, created, expires)
+	return nil
+}
+
+// SignRequestWithBody behaves like SignRequest, except that when the signer
+// is configured with a DigestAlgorithm it first computes the Digest header
+// over body and includes it in the signed header set, so that the signature
+// also attests to the integrity of the request body.
+func (m *macSigner) SignRequestWithBody(pKey crypto.PrivateKey, pubKeyId string, r *http.Request, body []byte) error {
+	headers := m.headers
+	if len(m.digestAlgorithm) > 0 {
+		var err error
+		headers, err = addDigest(r.Header, m.digestAlgorithm, body, headers)
+		if err != nil {
+			return err
+		}
+	}
+	created, expires := createdAndExpires(headers, m.expiresIn)
+	s, err := signatureString(r.Header, headers, addRequestTarget(r), created, expires)
 	if err != nil {
 		return err
 	}
@@ -52,12 +146,38 @@ func (m *macSigner) SignRequest(pKey crypto.PrivateKey, pubKeyId string, r *http
 	if err != nil {
 		return err
 	}
-	setSignatureHeader(r.Header, string(m.targetHeader), pubKeyId, m.m.String(), enc, m.headers)
+	setSignatureHeader(r.Header, string(m.targetHeader), m.authPrefix(), pubKeyId, m.m.String(), enc, headers, created, expires)
 	return nil
 }
 
 func (m *macSigner) SignResponse(pKey crypto.PrivateKey, pubKeyId string, r http.ResponseWriter) error {
-	s, err := m.signatureStringResponse(r)
+	created, expires := createdAndExpires(m.headers, m.expiresIn)
+	s, err := signatureString(r.Header(), m.headers, requestTargetNotPermitted, created, expires)
+	if err != nil {
+		return err
+	}
+	enc, err := m.signSignature(pKey, s)
+	if err != nil {
+		return err
+	}
+	setSignatureHeader(r.Header(), string(m.targetHeader), m.authPrefix(), pubKeyId, m.m.String(), enc, m.headers, created, expires)
+	return nil
+}
+
+// SignResponseWithBody behaves like SignResponse, except that when the
+// signer is configured with a DigestAlgorithm it first computes the Digest
+// header over body and includes it in the signed header set.
+func (m *macSigner) SignResponseWithBody(pKey crypto.PrivateKey, pubKeyId string, r http.ResponseWriter, body []byte) error {
+	headers := m.headers
+	if len(m.digestAlgorithm) > 0 {
+		var err error
+		headers, err = addDigest(r.Header(), m.digestAlgorithm, body, headers)
+		if err != nil {
+			return err
+		}
+	}
+	created, expires := createdAndExpires(headers, m.expiresIn)
+	s, err := signatureString(r.Header(), headers, requestTargetNotPermitted, created, expires)
 	if err != nil {
 		return err
 	}
@@ -65,7 +185,7 @@ func (m *macSigner) SignResponse(pKey crypto.PrivateKey, pubKeyId string, r http
 	if err != nil {
 		return err
 	}
-	setSignatureHeader(r.Header(), string(m.targetHeader), pubKeyId, m.m.String(), enc, m.headers)
+	setSignatureHeader(r.Header(), string(m.targetHeader), m.authPrefix(), pubKeyId, m.m.String(), enc, headers, created, expires)
 	return nil
 }
 
@@ -82,24 +202,58 @@ func (m *macSigner) signSignature(pKey crypto.PrivateKey, s string) (string, err
 	return enc, nil
 }
 
-func (m *macSigner) signatureString(r *http.Request) (string, error) {
-	return signatureString(r.Header, m.headers, addRequestTarget(r))
-}
-
-func (m *macSigner) signatureStringResponse(r http.ResponseWriter) (string, error) {
-	return signatureString(r.Header(), m.headers, requestTargetNotPermitted)
-}
-
 var _ Signer = &asymmSigner{}
 
 type asymmSigner struct {
-	s            signer
-	headers      []string
-	targetHeader SignatureScheme
+	s               signer
+	headers         []string
+	targetHeader    SignatureScheme
+	digestAlgorithm DigestAlgorithm
+	// prefix is the auth-scheme token written before the signature
+	// parameters, as required when targetHeader is Authorization. It is
+	// derived from targetHeader.authScheme() if left unset.
+	prefix string
+	// expiresIn, if positive, is the lifetime given to signatures via the
+	// expires= parameter and (expires) pseudo-header.
+	expiresIn time.Duration
+}
+
+func (a *asymmSigner) authPrefix() string {
+	if len(a.prefix) > 0 {
+		return a.prefix
+	}
+	return a.targetHeader.authScheme()
 }
 
 func (a *asymmSigner) SignRequest(pKey crypto.PrivateKey, pubKeyId string, r *http.Request) error {
-	s, err := a.signatureString(r)
+	created, expires := createdAndExpires(a.headers, a.expiresIn)
+	s, err := signatureString(r.Header, a.headers, addRequestTarget(r), created, expires)
+	if err != nil {
+		return err
+	}
+	enc, err := a.signSignature(pKey, s)
+	if err != nil {
+		return err
+	}
+	setSignatureHeader(r.Header, string(a.targetHeader), a.authPrefix(), pubKeyId, a.s.String(), enc, a.headers, created, expires)
+	return nil
+}
+
+// SignRequestWithBody behaves like SignRequest, except that when the signer
+// is configured with a DigestAlgorithm it first computes the Digest header
+// over body and includes it in the signed header set, so that the signature
+// also attests to the integrity of the request body.
+func (a *asymmSigner) SignRequestWithBody(pKey crypto.PrivateKey, pubKeyId string, r *http.Request, body []byte) error {
+	headers := a.headers
+	if len(a.digestAlgorithm) > 0 {
+		var err error
+		headers, err = addDigest(r.Header, a.digestAlgorithm, body, headers)
+		if err != nil {
+			return err
+		}
+	}
+	created, expires := createdAndExpires(headers, a.expiresIn)
+	s, err := signatureString(r.Header, headers, addRequestTarget(r), created, expires)
 	if err != nil {
 		return err
 	}
@@ -107,12 +261,38 @@ func (a *asymmSigner) SignRequest(pKey crypto.PrivateKey, pubKeyId string, r *ht
 	if err != nil {
 		return err
 	}
-	setSignatureHeader(r.Header, string(a.targetHeader), pubKeyId, a.s.String(), enc, a.headers)
+	setSignatureHeader(r.Header, string(a.targetHeader), a.authPrefix(), pubKeyId, a.s.String(), enc, headers, created, expires)
 	return nil
 }
 
 func (a *asymmSigner) SignResponse(pKey crypto.PrivateKey, pubKeyId string, r http.ResponseWriter) error {
-	s, err := a.signatureStringResponse(r)
+	created, expires := createdAndExpires(a.headers, a.expiresIn)
+	s, err := signatureString(r.Header(), a.headers, requestTargetNotPermitted, created, expires)
+	if err != nil {
+		return err
+	}
+	enc, err := a.signSignature(pKey, s)
+	if err != nil {
+		return err
+	}
+	setSignatureHeader(r.Header(), string(a.targetHeader), a.authPrefix(), pubKeyId, a.s.String(), enc, a.headers, created, expires)
+	return nil
+}
+
+// SignResponseWithBody behaves like SignResponse, except that when the
+// signer is configured with a DigestAlgorithm it first computes the Digest
+// header over body and includes it in the signed header set.
+func (a *asymmSigner) SignResponseWithBody(pKey crypto.PrivateKey, pubKeyId string, r http.ResponseWriter, body []byte) error {
+	headers := a.headers
+	if len(a.digestAlgorithm) > 0 {
+		var err error
+		headers, err = addDigest(r.Header(), a.digestAlgorithm, body, headers)
+		if err != nil {
+			return err
+		}
+	}
+	created, expires := createdAndExpires(headers, a.expiresIn)
+	s, err := signatureString(r.Header(), headers, requestTargetNotPermitted, created, expires)
 	if err != nil {
 		return err
 	}
@@ -120,7 +300,7 @@ func (a *asymmSigner) SignResponse(pKey crypto.PrivateKey, pubKeyId string, r ht
 	if err != nil {
 		return err
 	}
-	setSignatureHeader(r.Header(), string(a.targetHeader), pubKeyId, a.s.String(), enc, a.headers)
+	setSignatureHeader(r.Header(), string(a.targetHeader), a.authPrefix(), pubKeyId, a.s.String(), enc, headers, created, expires)
 	return nil
 }
 
@@ -133,15 +313,7 @@ func (a *asymmSigner) signSignature(pKey crypto.PrivateKey, s string) (string, e
 	return enc, nil
 }
 
-func (a *asymmSigner) signatureString(r *http.Request) (string, error) {
-	return signatureString(r.Header, a.headers, addRequestTarget(r))
-}
-
-func (a *asymmSigner) signatureStringResponse(r http.ResponseWriter) (string, error) {
-	return signatureString(r.Header(), a.headers, requestTargetNotPermitted)
-}
-
-func setSignatureHeader(h http.Header, targetHeader, pubKeyId, algo, enc string, headers []string) {
+func setSignatureHeader(h http.Header, targetHeader, prefix, pubKeyId, algo, enc string, headers []string, created, expires int64) {
 	if len(headers) == 0 {
 		headers = defaultHeaders
 	}
@@ -173,21 +345,39 @@ func setSignatureHeader(h http.Header, targetHeader, pubKeyId, algo, enc string,
 	}
 	b.WriteString(parameterValueDelimiter)
 	b.WriteString(parameterSeparater)
+	// Created
+	if created != 0 {
+		b.WriteString(createdParameter)
+		b.WriteString(parameterKVSeparater)
+		b.WriteString(strconv.FormatInt(created, 10))
+		b.WriteString(parameterSeparater)
+	}
+	// Expires
+	if expires != 0 {
+		b.WriteString(expiresParameter)
+		b.WriteString(parameterKVSeparater)
+		b.WriteString(strconv.FormatInt(expires, 10))
+		b.WriteString(parameterSeparater)
+	}
 	// Signature
 	b.WriteString(signatureParameter)
 	b.WriteString(parameterKVSeparater)
 	b.WriteString(parameterValueDelimiter)
 	b.WriteString(enc)
 	b.WriteString(parameterValueDelimiter)
-	h.Add(targetHeader, b.String())
+	if len(prefix) > 0 {
+		h.Add(targetHeader, prefix+headerParameterValueDelim+b.String())
+	} else {
+		h.Add(targetHeader, b.String())
+	}
 }
 
-func requestTargetNotPermitted(b bytes.Buffer) error {
+func requestTargetNotPermitted(b *bytes.Buffer) error {
 	return fmt.Errorf("cannot sign with %q on anything other than an http request", RequestTarget)
 }
 
-func addRequestTarget(r *http.Request) func(b bytes.Buffer) error {
-	return func(b bytes.Buffer) error {
+func addRequestTarget(r *http.Request) func(b *bytes.Buffer) error {
+	return func(b *bytes.Buffer) error {
 		b.WriteString(RequestTarget)
 		b.WriteString(headerFieldDelimiter)
 		b.WriteString(strings.ToLower(r.Method))
@@ -197,7 +387,23 @@ func addRequestTarget(r *http.Request) func(b bytes.Buffer) error {
 	}
 }
 
-func signatureString(values http.Header, include []string, requestTargetFn func(b bytes.Buffer) error) (string, error) {
+// addRequestTargetPathOnly behaves like addRequestTarget, except it builds
+// the (request-target) line from the URL path alone, dropping the query
+// string. It backs the fallback verification attempt of
+// NewVerifierTolerateQueryStrip, which matches signatures whose
+// (request-target) was computed without the query string.
+func addRequestTargetPathOnly(r *http.Request) func(b *bytes.Buffer) error {
+	return func(b *bytes.Buffer) error {
+		b.WriteString(RequestTarget)
+		b.WriteString(headerFieldDelimiter)
+		b.WriteString(strings.ToLower(r.Method))
+		b.WriteString(requestTargetSeparator)
+		b.WriteString(r.URL.Path)
+		return nil
+	}
+}
+
+func signatureString(values http.Header, include []string, requestTargetFn func(b *bytes.Buffer) error, created, expires int64) (string, error) {
 	if len(include) == 0 {
 		include = defaultHeaders
 	}
@@ -205,10 +411,24 @@ func signatureString(values http.Header, include []string, requestTargetFn func(
 	for n, i := range include {
 		i := strings.ToLower(i)
 		if i == RequestTarget {
-			err := requestTargetFn(b)
+			err := requestTargetFn(&b)
 			if err != nil {
 				return "", err
 			}
+		} else if i == Created {
+			if created == 0 {
+				return "", fmt.Errorf("cannot sign %q without a created time", Created)
+			}
+			b.WriteString(Created)
+			b.WriteString(headerFieldDelimiter)
+			b.WriteString(strconv.FormatInt(created, 10))
+		} else if i == Expires {
+			if expires == 0 {
+				return "", fmt.Errorf("cannot sign %q without an expires time", Expires)
+			}
+			b.WriteString(Expires)
+			b.WriteString(headerFieldDelimiter)
+			b.WriteString(strconv.FormatInt(expires, 10))
 		} else {
 			hv, ok := values[textproto.CanonicalMIMEHeaderKey(i)]
 			if !ok {
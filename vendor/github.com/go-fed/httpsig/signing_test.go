@@ -0,0 +1,67 @@
+package httpsig
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAuthSchemeDefaultsToSignatureOnAuthorization(t *testing.T) {
+	if got := Authorization.authScheme(); got != "Signature" {
+		t.Errorf("Authorization.authScheme() = %q, want %q", got, "Signature")
+	}
+	if got := Signature.authScheme(); got != "" {
+		t.Errorf("Signature.authScheme() = %q, want empty string", got)
+	}
+}
+
+func TestAuthPrefixDerivesFromTargetHeaderUnlessSet(t *testing.T) {
+	m := &macSigner{targetHeader: Authorization}
+	if got := m.authPrefix(); got != "Signature" {
+		t.Errorf("macSigner.authPrefix() = %q, want %q", got, "Signature")
+	}
+
+	m = &macSigner{targetHeader: Signature}
+	if got := m.authPrefix(); got != "" {
+		t.Errorf("macSigner.authPrefix() with Signature target = %q, want empty", got)
+	}
+
+	// An explicit prefix always wins, even over Authorization's default.
+	m = &macSigner{targetHeader: Authorization, prefix: "Custom"}
+	if got := m.authPrefix(); got != "Custom" {
+		t.Errorf("macSigner.authPrefix() with explicit prefix = %q, want %q", got, "Custom")
+	}
+
+	a := &asymmSigner{targetHeader: Authorization}
+	if got := a.authPrefix(); got != "Signature" {
+		t.Errorf("asymmSigner.authPrefix() = %q, want %q", got, "Signature")
+	}
+
+	a = &asymmSigner{targetHeader: Authorization, prefix: "Custom"}
+	if got := a.authPrefix(); got != "Custom" {
+		t.Errorf("asymmSigner.authPrefix() with explicit prefix = %q, want %q", got, "Custom")
+	}
+}
+
+func TestSetSignatureHeaderWritesAuthSchemePrefix(t *testing.T) {
+	h := make(http.Header)
+	setSignatureHeader(h, string(Authorization), "Signature", "my-key-id", "hs2019", "deadbeef", []string{dateHeader}, 0, 0)
+	got := h.Get(string(Authorization))
+	if got == "" {
+		t.Fatal("setSignatureHeader did not set the Authorization header")
+	}
+	if want := "Signature "; len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("Authorization value %q does not start with auth-scheme prefix %q", got, want)
+	}
+}
+
+func TestSetSignatureHeaderOmitsPrefixOnSignatureHeader(t *testing.T) {
+	h := make(http.Header)
+	setSignatureHeader(h, string(Signature), "", "my-key-id", "hs2019", "deadbeef", []string{dateHeader}, 0, 0)
+	got := h.Get(string(Signature))
+	if got == "" {
+		t.Fatal("setSignatureHeader did not set the Signature header")
+	}
+	if want := "keyId=\""; len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("Signature value %q should start directly with the keyId parameter, got an unexpected prefix", got)
+	}
+}
@@ -0,0 +1,164 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// digestReader wraps an io.Reader, incrementally hashing bytes as they pass
+// through Read so that the digest can be finalized once the body has been
+// fully consumed, without ever buffering it in memory. This mirrors the
+// layered payload-hash pattern used by AWS SigV4 chunked signing. Once Read
+// first observes io.EOF, it hands the base64-encoded sum to onDone; an
+// error from onDone is surfaced in place of io.EOF so the caller learns
+// signing failed instead of believing the body was sent successfully.
+type digestReader struct {
+	r      io.Reader
+	h      hash.Hash
+	onDone func(sum string) error
+	done   bool
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, rerr := d.r.Read(p)
+	if n > 0 {
+		d.h.Write(p[:n])
+	}
+	if rerr == io.EOF && !d.done {
+		d.done = true
+		sum := base64.StdEncoding.EncodeToString(d.h.Sum(nil))
+		if err := d.onDone(sum); err != nil {
+			return n, err
+		}
+	}
+	return n, rerr
+}
+
+// targetHeaderNamer is implemented by this package's own Signer
+// implementations so that SignRequestStreaming can learn which header
+// (Signature or Authorization) a given Signer writes its signature to, in
+// order to pre-declare only that header as a trailer. Signers from outside
+// this package fall back to announcing both.
+type targetHeaderNamer interface {
+	targetHeaderName() string
+}
+
+func (m *macSigner) targetHeaderName() string   { return string(m.targetHeader) }
+func (a *asymmSigner) targetHeaderName() string { return string(a.targetHeader) }
+
+// SignRequestStreaming wraps body in an io.Reader that hashes it
+// incrementally as the caller streams it out to the wire, and once the
+// body reaches io.EOF, signs r and delivers the Digest and Signature (or
+// Authorization) values as HTTP trailers rather than leading headers. This
+// is what makes it safe for large request bodies: by the time the digest
+// is known, net/http has already flushed r.Header to the wire, so there is
+// no way for headers computed here to still reach the peer except via
+// r.Trailer, which net/http lets a request body populate as it is
+// drained. SignRequestStreaming pre-declares the trailer keys it will set
+// so they are announced up front, as net/http requires; the caller must
+// leave r.Trailer alone afterwards and read the returned stream to
+// completion (e.g. by sending r through an http.Client) before the
+// signature is actually computed. s must already be configured with the
+// digest header name in its signed header set; unlike SignRequestWithBody,
+// this does not add it.
+func SignRequestStreaming(s Signer, pKey crypto.PrivateKey, keyId string, r *http.Request, algo DigestAlgorithm, body io.Reader) (io.Reader, error) {
+	hasher, err := algo.new()
+	if err != nil {
+		return nil, err
+	}
+	sigHeaderNames := []string{string(Signature), string(Authorization)}
+	if n, ok := s.(targetHeaderNamer); ok {
+		sigHeaderNames = []string{n.targetHeaderName()}
+	}
+	if r.Trailer == nil {
+		r.Trailer = make(http.Header)
+	}
+	r.Trailer.Set(digestHeader, "")
+	for _, name := range sigHeaderNames {
+		r.Trailer.Set(name, "")
+	}
+	dr := &digestReader{
+		r: body,
+		h: hasher,
+		onDone: func(sum string) error {
+			r.Header.Set(digestHeader, fmt.Sprintf("%s=%s", algo, sum))
+			if err := s.SignRequest(pKey, keyId, r); err != nil {
+				return err
+			}
+			r.Trailer.Set(digestHeader, r.Header.Get(digestHeader))
+			r.Header.Del(digestHeader)
+			for _, name := range sigHeaderNames {
+				if v := r.Header.Get(name); len(v) > 0 {
+					r.Trailer.Set(name, v)
+					r.Header.Del(name)
+				}
+			}
+			return nil
+		},
+	}
+	return dr, nil
+}
+
+// VerifyStreaming hashes r.Body with digestAlgo as it is drained and, once
+// r.Body reaches io.EOF, builds a verifier from the Digest and
+// Signature/Authorization values the signer could only have delivered as
+// HTTP trailers (see SignRequestStreaming), compares the recomputed digest
+// against the announced one, and then verifies the signature.
+//
+// Unlike Verify, this is a package-level function rather than a *verifier
+// method: a *verifier built from r.Header before r.Body is read would
+// never see a signature, because SignRequestStreaming cannot put one in
+// r.Header before the body exists to hash over — it only ever arrives via
+// r.Trailer, which net/http itself does not populate until r.Body has been
+// read to completion. So there is no way to construct a *verifier to call
+// this on until after the very draining this function performs; it builds
+// one itself once that's done.
+//
+// digestAlgo is supplied by the caller rather than trusted to the Digest
+// trailer, since that header is unauthenticated until the signature
+// beneath it has been checked.
+func VerifyStreaming(r *http.Request, pKey crypto.PublicKey, algo Algorithm, digestAlgo DigestAlgorithm) error {
+	hasher, err := digestAlgo.new()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(hasher, r.Body); err != nil {
+		return err
+	}
+	actual := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+
+	digestValue := r.Trailer.Get(digestHeader)
+	if len(digestValue) == 0 {
+		return fmt.Errorf("missing %q trailer required for streaming body verification", digestHeader)
+	}
+	gotAlgo, sum, ok := parseDigestHeader(digestValue)
+	if !ok {
+		return fmt.Errorf("malformed %q header: %q", digestHeader, digestValue)
+	}
+	if !strings.EqualFold(string(gotAlgo), string(digestAlgo)) {
+		return fmt.Errorf("expected %q digest algorithm, got %q", digestAlgo, gotAlgo)
+	}
+	if !hmac.Equal([]byte(actual), []byte(sum)) {
+		return fmt.Errorf("body does not match %q header", digestHeader)
+	}
+
+	merged := r.Header.Clone()
+	for k, vals := range r.Trailer {
+		if len(vals) > 0 {
+			merged[k] = vals
+		}
+	}
+	v, err := newVerifier(merged, func(h http.Header, headers []string, created, expires int64) (string, error) {
+		return signatureString(h, headers, addRequestTarget(r), created, expires)
+	})
+	if err != nil {
+		return err
+	}
+	return v.Verify(pKey, algo)
+}
@@ -0,0 +1,92 @@
+package httpsig
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreatedAndExpiresGatedOnHeaders(t *testing.T) {
+	created, expires := createdAndExpires(nil, time.Minute)
+	if created != 0 || expires != 0 {
+		t.Fatalf("createdAndExpires with no opt-in = (%d, %d), want (0, 0)", created, expires)
+	}
+
+	created, expires = createdAndExpires([]string{Created}, time.Minute)
+	if created == 0 {
+		t.Error("createdAndExpires did not set created when (created) is in headers")
+	}
+	if expires != 0 {
+		t.Error("createdAndExpires set expires without (expires) in headers")
+	}
+
+	created, expires = createdAndExpires([]string{Created, Expires}, time.Minute)
+	if created == 0 || expires == 0 {
+		t.Fatalf("createdAndExpires with both opted in = (%d, %d), want both non-zero", created, expires)
+	}
+	if expires <= created {
+		t.Errorf("expires (%d) should be after created (%d)", expires, created)
+	}
+
+	// expiresIn <= 0 must not produce an expires value even when opted in.
+	created, expires = createdAndExpires([]string{Created, Expires}, 0)
+	if created == 0 {
+		t.Error("createdAndExpires did not set created")
+	}
+	if expires != 0 {
+		t.Errorf("createdAndExpires set expires = %d with a non-positive expiresIn, want 0", expires)
+	}
+}
+
+func TestCheckTimestampsIgnoresUnsignedCreated(t *testing.T) {
+	// A created value arriving without (created) ever having been part of
+	// the signed header set must not be enforced: nothing attested to it.
+	v := &verifier{headers: []string{dateHeader}, created: time.Now().Unix() + 1e9}
+	if err := v.checkTimestamps(); err != nil {
+		t.Errorf("checkTimestamps rejected an unsigned created value: %v", err)
+	}
+}
+
+func TestCheckTimestampsIgnoresUnsignedExpires(t *testing.T) {
+	v := &verifier{headers: []string{dateHeader}, expires: time.Now().Unix() - 1}
+	if err := v.checkTimestamps(); err != nil {
+		t.Errorf("checkTimestamps rejected an unsigned expires value: %v", err)
+	}
+}
+
+func TestCheckTimestampsRejectsExpiredSignature(t *testing.T) {
+	v := &verifier{headers: []string{dateHeader, Expires}, expires: time.Now().Unix() - 1}
+	if err := v.checkTimestamps(); err == nil {
+		t.Error("checkTimestamps accepted an expired signature, want error")
+	}
+}
+
+func TestCheckTimestampsRejectsFutureCreatedBeyondClockSkew(t *testing.T) {
+	v := &verifier{
+		headers: []string{dateHeader, Created},
+		created: time.Now().Unix() + 100,
+	}
+	if err := v.checkTimestamps(); err == nil {
+		t.Error("checkTimestamps accepted a created time far in the future with zero clock skew, want error")
+	}
+
+	v.SetClockSkew(5 * time.Minute)
+	if err := v.checkTimestamps(); err != nil {
+		t.Errorf("checkTimestamps rejected a created time within the configured clock skew: %v", err)
+	}
+}
+
+func TestCheckTimestampsEnforcesMaxAge(t *testing.T) {
+	v := &verifier{
+		headers: []string{dateHeader, Created},
+		created: time.Now().Unix() - 3600,
+	}
+	v.SetMaxAge(time.Minute)
+	if err := v.checkTimestamps(); err == nil {
+		t.Error("checkTimestamps accepted a signature older than maxAge, want error")
+	}
+
+	v.created = time.Now().Unix()
+	if err := v.checkTimestamps(); err != nil {
+		t.Errorf("checkTimestamps rejected a fresh signature: %v", err)
+	}
+}
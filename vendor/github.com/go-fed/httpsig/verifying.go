@@ -2,10 +2,13 @@ package httpsig
 
 import (
 	"crypto"
+	"crypto/hmac"
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var _ Verifier = &verifier{}
@@ -15,15 +18,28 @@ type verifier struct {
 	kId         string
 	signature   string
 	headers     []string
-	sigStringFn func(http.Header, []string) (string, error)
+	created     int64
+	expires     int64
+	sigStringFn func(http.Header, []string, int64, int64) (string, error)
+	// fallbackSigStringFn, if set, is tried once if sigStringFn fails to
+	// validate. It backs NewVerifierTolerateQueryStrip's retry against a
+	// (request-target) computed without the query string.
+	fallbackSigStringFn func(http.Header, []string, int64, int64) (string, error)
+	// clockSkew bounds how far into the future a created time may be
+	// before it is rejected. Defaults to zero (no tolerance).
+	clockSkew time.Duration
+	// maxAge, if positive, rejects signatures older than this duration,
+	// measured from created if (created) is among the signed headers, or
+	// else from the Date header.
+	maxAge time.Duration
 }
 
-func newVerifier(h http.Header, sigStringFn func(http.Header, []string) (string, error)) (*verifier, error) {
+func newVerifier(h http.Header, sigStringFn func(http.Header, []string, int64, int64) (string, error)) (*verifier, error) {
 	s, err := getSignatureScheme(h)
 	if err != nil {
 		return nil, err
 	}
-	kId, sig, headers, err := getSignatureComponents(s)
+	kId, sig, headers, created, expires, err := getSignatureComponents(s)
 	if err != nil {
 		return nil, err
 	}
@@ -32,15 +48,55 @@ func newVerifier(h http.Header, sigStringFn func(http.Header, []string) (string,
 		kId:         kId,
 		signature:   sig,
 		headers:     headers,
+		created:     created,
+		expires:     expires,
 		sigStringFn: sigStringFn,
 	}, nil
 }
 
+// NewVerifierTolerateQueryStrip behaves like NewVerifier, except that if the
+// signature fails to validate against the exact (request-target) derived
+// from r, it retries once against a (request-target) built from r.URL.Path
+// alone before reporting failure. The retry validates only signatures whose
+// (request-target) was the bare path, such as those from a signer that
+// dropped the query string before signing; a signature computed over a URL
+// with a query string still fails if that query string is dropped or
+// re-encoded in transit. Strict callers that want the current behavior
+// should keep using NewVerifier.
+func NewVerifierTolerateQueryStrip(r *http.Request) (Verifier, error) {
+	v, err := newVerifier(r.Header, func(h http.Header, headers []string, created, expires int64) (string, error) {
+		return signatureString(h, headers, addRequestTarget(r), created, expires)
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.fallbackSigStringFn = func(h http.Header, headers []string, created, expires int64) (string, error) {
+		return signatureString(h, headers, addRequestTargetPathOnly(r), created, expires)
+	}
+	return v, nil
+}
+
 func (v *verifier) KeyId() string {
 	return v.kId
 }
 
+// SetMaxAge rejects signatures older than d, measured from the created
+// parameter if (created) is among the signed headers, or else from the Date
+// header. A zero d disables the check.
+func (v *verifier) SetMaxAge(d time.Duration) {
+	v.maxAge = d
+}
+
+// SetClockSkew bounds how far into the future a created time may be before
+// Verify rejects it, to tolerate clock drift between signer and verifier.
+func (v *verifier) SetClockSkew(d time.Duration) {
+	v.clockSkew = d
+}
+
 func (v *verifier) Verify(pKey crypto.PublicKey, algo Algorithm) error {
+	if err := v.checkTimestamps(); err != nil {
+		return err
+	}
 	s, err := signerFromString(string(algo))
 	if err == nil {
 		return v.asymmVerify(s, pKey)
@@ -52,18 +108,102 @@ func (v *verifier) Verify(pKey crypto.PublicKey, algo Algorithm) error {
 	return fmt.Errorf("no crypto implementation available for %q", algo)
 }
 
+// checkTimestamps rejects signatures whose expires parameter is in the
+// past, whose created parameter is further in the future than clockSkew
+// allows, or that are older than maxAge. This closes the replay window left
+// open by a bare Date header, which a replaying attacker can simply leave
+// untouched. The expires/created checks only apply if the signer actually
+// opted into (expires)/(created) by including them in the signed header
+// set; a bare non-zero v.created/v.expires is not enough, since a signer
+// that never added the pseudo-header never attested to that timestamp in
+// the first place.
+func (v *verifier) checkTimestamps() error {
+	now := time.Now().Unix()
+	if headersInclude(v.headers, Expires) && v.expires != 0 && now > v.expires {
+		return fmt.Errorf("signature expired at %d", v.expires)
+	}
+	if headersInclude(v.headers, Created) && v.created != 0 {
+		if v.created > now+int64(v.clockSkew.Seconds()) {
+			return fmt.Errorf("signature created time %d is too far in the future", v.created)
+		}
+		if v.maxAge > 0 && now-v.created > int64(v.maxAge.Seconds()) {
+			return fmt.Errorf("signature is older than the maximum age of %s", v.maxAge)
+		}
+		return nil
+	}
+	if v.maxAge > 0 {
+		d := v.header.Get("Date")
+		if len(d) == 0 {
+			return fmt.Errorf("missing %q header required to enforce max age", "Date")
+		}
+		t, err := http.ParseTime(d)
+		if err != nil {
+			return fmt.Errorf("could not parse %q header: %w", "Date", err)
+		}
+		if now-t.Unix() > int64(v.maxAge.Seconds()) {
+			return fmt.Errorf("signature is older than the maximum age of %s", v.maxAge)
+		}
+	}
+	return nil
+}
+
+// VerifyWithBody behaves like Verify, except it first recomputes the digest
+// of body and constant-time compares it against the Digest header, failing
+// closed if the header is missing, malformed, or does not match before the
+// signature itself is ever checked.
+func (v *verifier) VerifyWithBody(pKey crypto.PublicKey, algo Algorithm, body []byte) error {
+	if err := v.verifyDigest(body); err != nil {
+		return err
+	}
+	return v.Verify(pKey, algo)
+}
+
+func (v *verifier) verifyDigest(body []byte) error {
+	digestValue := v.header.Get(digestHeader)
+	if len(digestValue) == 0 {
+		return fmt.Errorf("missing %q header required to verify the request body", digestHeader)
+	}
+	algo, sum, ok := parseDigestHeader(digestValue)
+	if !ok {
+		return fmt.Errorf("malformed %q header: %q", digestHeader, digestValue)
+	}
+	hasher, err := algo.new()
+	if err != nil {
+		return err
+	}
+	hasher.Write(body)
+	actual := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+	if !hmac.Equal([]byte(actual), []byte(sum)) {
+		return fmt.Errorf("body does not match %q header", digestHeader)
+	}
+	return nil
+}
+
 func (v *verifier) macVerify(m macer, pKey crypto.PublicKey) error {
 	key, ok := pKey.([]byte)
 	if !ok {
 		return fmt.Errorf("public key for MAC verifying must be of type []byte")
 	}
-	signature, err := v.sigStringFn(v.header, v.headers)
+	actualMAC, err := base64.StdEncoding.DecodeString(v.signature)
 	if err != nil {
 		return err
 	}
-	actualMAC, err := base64.StdEncoding.DecodeString(v.signature)
+	signature, err := v.sigStringFn(v.header, v.headers, v.created, v.expires)
+	if err != nil {
+		return err
+	}
+	ok, err = m.Equal([]byte(signature), actualMAC, key)
 	if err != nil {
 		return err
+	} else if ok {
+		return nil
+	}
+	if v.fallbackSigStringFn == nil {
+		return fmt.Errorf("invalid http signature")
+	}
+	signature, err = v.fallbackSigStringFn(v.header, v.headers, v.created, v.expires)
+	if err != nil {
+		return fmt.Errorf("invalid http signature")
 	}
 	ok, err = m.Equal([]byte(signature), actualMAC, key)
 	if err != nil {
@@ -75,19 +215,26 @@ func (v *verifier) macVerify(m macer, pKey crypto.PublicKey) error {
 }
 
 func (v *verifier) asymmVerify(s signer, pKey crypto.PublicKey) error {
-	toHash, err := v.sigStringFn(v.header, v.headers)
+	signature, err := base64.StdEncoding.DecodeString(v.signature)
 	if err != nil {
 		return err
 	}
-	signature, err := base64.StdEncoding.DecodeString(v.signature)
+	toHash, err := v.sigStringFn(v.header, v.headers, v.created, v.expires)
 	if err != nil {
 		return err
 	}
 	err = s.Verify(pKey, []byte(toHash), signature)
-	if err != nil {
+	if err == nil {
+		return nil
+	}
+	if v.fallbackSigStringFn == nil {
 		return err
 	}
-	return nil
+	toHash, ferr := v.fallbackSigStringFn(v.header, v.headers, v.created, v.expires)
+	if ferr != nil {
+		return err
+	}
+	return s.Verify(pKey, []byte(toHash), signature)
 }
 
 func getSignatureScheme(h http.Header) (string, error) {
@@ -106,11 +253,12 @@ func getSignatureScheme(h http.Header) (string, error) {
 	} else if sigHasAll {
 		return s, nil
 	} else { // authHasAll
+		a = strings.TrimPrefix(a, Authorization.authScheme()+headerParameterValueDelim)
 		return a, nil
 	}
 }
 
-func getSignatureComponents(s string) (kId, sig string, headers []string, err error) {
+func getSignatureComponents(s string) (kId, sig string, headers []string, created, expires int64, err error) {
 	params := strings.Split(s, parameterSeparater)
 	for _, p := range params {
 		kv := strings.SplitN(p, parameterKVSeparater, 2)
@@ -129,6 +277,18 @@ func getSignatureComponents(s string) (kId, sig string, headers []string, err er
 			headers = strings.Split(v, headerParameterValueDelim)
 		case signatureParameter:
 			sig = v
+		case createdParameter:
+			created, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("malformed %q parameter: %w", createdParameter, err)
+				return
+			}
+		case expiresParameter:
+			expires, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("malformed %q parameter: %w", expiresParameter, err)
+				return
+			}
 		default:
 			// Ignore unrecognized parameters
 		}